@@ -0,0 +1,46 @@
+package client
+
+import "context"
+
+// APIKey mirrors the records returned by GET /api_keys.
+type APIKey struct {
+	Key       string `json:"key"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+}
+
+// APIKeyClient is the typed equivalent of the /api_keys routes.
+type APIKeyClient interface {
+	Create(ctx context.Context, name string) (APIKey, error)
+	List(ctx context.Context) ([]APIKey, error)
+	Delete(ctx context.Context, key string) error
+	Check(ctx context.Context, key string) (bool, error)
+}
+
+type apiKeyClient struct {
+	client *client
+}
+
+func (a *apiKeyClient) Create(ctx context.Context, name string) (APIKey, error) {
+	var created APIKey
+	err := a.client.do(ctx, "POST", "/api_keys", map[string]string{"name": name}, &created)
+	return created, err
+}
+
+func (a *apiKeyClient) List(ctx context.Context) ([]APIKey, error) {
+	var keys []APIKey
+	err := a.client.do(ctx, "GET", "/api_keys", nil, &keys)
+	return keys, err
+}
+
+func (a *apiKeyClient) Delete(ctx context.Context, key string) error {
+	return a.client.do(ctx, "DELETE", "/api_keys?key="+key, nil, nil)
+}
+
+func (a *apiKeyClient) Check(ctx context.Context, key string) (bool, error) {
+	var result struct {
+		Valid bool `json:"valid"`
+	}
+	err := a.client.do(ctx, "GET", "/api_keys/check?key="+key, nil, &result)
+	return result.Valid, err
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/lukemarsden/helix/api/pkg/progress"
+)
+
+// streamSessionEvents decodes the ND-JSON body of GET /sessions/{id}/events
+// into a channel of progress.Event, closing it once the server closes the
+// connection or ctx is cancelled.
+func (c *client) streamSessionEvents(ctx context.Context, sessionID string) (<-chan progress.Event, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/sessions/"+sessionID+"/events", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", c.authHeader)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan progress.Event)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var event progress.Event
+			if err := decoder.Decode(&event); err != nil {
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
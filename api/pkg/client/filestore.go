@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/url"
+)
+
+// FilestoreEntry mirrors the entries returned by GET /filestore/list and /filestore/get.
+type FilestoreEntry struct {
+	Path  string `json:"path"`
+	Name  string `json:"name"`
+	IsDir bool   `json:"directory"`
+	Size  int64  `json:"size"`
+}
+
+// FilestoreClient is the typed equivalent of the /filestore routes.
+type FilestoreClient interface {
+	List(ctx context.Context, path string) ([]FilestoreEntry, error)
+	Get(ctx context.Context, path string) (FilestoreEntry, error)
+	Upload(ctx context.Context, path string, content io.Reader, size int64) (Operation, error)
+	Rename(ctx context.Context, path, newPath string) error
+	Delete(ctx context.Context, path string) error
+	CreateFolder(ctx context.Context, path string) error
+}
+
+type filestoreClient struct {
+	client *client
+}
+
+func (f *filestoreClient) List(ctx context.Context, path string) ([]FilestoreEntry, error) {
+	var entries []FilestoreEntry
+	err := f.client.do(ctx, "GET", "/filestore/list?path="+url.QueryEscape(path), nil, &entries)
+	return entries, err
+}
+
+func (f *filestoreClient) Get(ctx context.Context, path string) (FilestoreEntry, error) {
+	var entry FilestoreEntry
+	err := f.client.do(ctx, "GET", "/filestore/get?path="+url.QueryEscape(path), nil, &entry)
+	return entry, err
+}
+
+// Upload performs a tus resumable upload (see pkg/server/tus.go) so large
+// finetuning inputs survive a dropped connection, returning an Operation the
+// caller can watch for upload progress.
+func (f *filestoreClient) Upload(ctx context.Context, path string, content io.Reader, size int64) (Operation, error) {
+	location, err := f.client.createTusUpload(ctx, "/filestore/upload", size, map[string]string{"path": path})
+	if err != nil {
+		return nil, err
+	}
+	if err := f.client.uploadWithResume(ctx, location, content, size); err != nil {
+		return nil, err
+	}
+	return newUploadOperation(size), nil
+}
+
+func (f *filestoreClient) Rename(ctx context.Context, path, newPath string) error {
+	return f.client.do(ctx, "PUT", "/filestore/rename", map[string]string{"path": path, "new_path": newPath}, nil)
+}
+
+func (f *filestoreClient) Delete(ctx context.Context, path string) error {
+	return f.client.do(ctx, "DELETE", "/filestore/delete?path="+url.QueryEscape(path), nil, nil)
+}
+
+func (f *filestoreClient) CreateFolder(ctx context.Context, path string) error {
+	return f.client.do(ctx, "POST", "/filestore/folder", map[string]string{"path": path}, nil)
+}
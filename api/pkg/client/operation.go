@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+
+	"github.com/lukemarsden/helix/api/pkg/progress"
+)
+
+// Operation tracks a long-running action (create session, upload) so callers
+// can render progress uniformly regardless of which action started it.
+type Operation interface {
+	// Wait blocks until the operation reaches a terminal state, or ctx is
+	// cancelled.
+	Wait(ctx context.Context) error
+	// Cancel requests that the server-side action stop. Not every action can
+	// actually be cancelled once started.
+	Cancel(ctx context.Context) error
+	// Progress streams the operation's progress.Event frames as they arrive.
+	// It can be read independently of Wait; frames that arrive while its
+	// buffer is full are dropped rather than delivered late.
+	Progress() <-chan progress.Event
+}
+
+type operation struct {
+	sessionID string
+	client    *client
+	events    chan progress.Event
+	done      chan error
+	cancel    context.CancelFunc
+}
+
+func newOperation(c *client, sessionID string) *operation {
+	ctx, cancel := context.WithCancel(context.Background())
+	op := &operation{
+		sessionID: sessionID,
+		client:    c,
+		events:    make(chan progress.Event, 64),
+		done:      make(chan error, 1),
+		cancel:    cancel,
+	}
+	go op.run(ctx)
+	return op
+}
+
+func (op *operation) run(ctx context.Context) {
+	defer close(op.events)
+
+	events, err := op.client.streamSessionEvents(ctx, op.sessionID)
+	if err != nil {
+		op.done <- err
+		return
+	}
+
+	for event := range events {
+		op.publish(event)
+		if event.Status == "finished" || event.ErrorDetail != nil {
+			op.done <- nil
+			return
+		}
+	}
+	op.done <- nil
+}
+
+// publish forwards event to Progress()'s channel without blocking. Progress
+// is documented as independently usable from Wait, so a caller that never
+// drains it must not be able to wedge run() - and thus Wait, which depends
+// on run() reaching a terminal event - once the buffer fills. Dropping the
+// event is safe here: Wait only observes op.done, never op.events.
+func (op *operation) publish(event progress.Event) {
+	select {
+	case op.events <- event:
+	default:
+	}
+}
+
+func (op *operation) Wait(ctx context.Context) error {
+	select {
+	case err := <-op.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Cancel requests server-side cancellation and tears down the local event
+// stream: cancelling op's context aborts the underlying GET
+// /sessions/{id}/events request run() is blocked reading from, so its
+// goroutine and HTTP connection don't leak past Cancel returning.
+func (op *operation) Cancel(ctx context.Context) error {
+	defer op.cancel()
+	return op.client.do(ctx, "DELETE", "/sessions/"+op.sessionID, nil, nil)
+}
+
+func (op *operation) Progress() <-chan progress.Event {
+	return op.events
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/bacalhau-project/lilysaas/api/pkg/types"
+)
+
+// RunnerClient is the typed equivalent of the /runner/{runnerid} routes,
+// authenticated with the runner JWTs minted by pkg/server/runnertoken rather
+// than a user's keycloak token.
+type RunnerClient interface {
+	NextSession(ctx context.Context, modelName, mode, taskType string) (types.Session, error)
+	Respond(ctx context.Context, sessionID string, response interface{}) error
+	Download(ctx context.Context, sessionID, path string) (io.ReadCloser, error)
+	Upload(ctx context.Context, sessionID, filename string, content io.Reader, size int64) (Operation, error)
+}
+
+type runnerClient struct {
+	client   *client
+	runnerID string
+}
+
+func (r *runnerClient) NextSession(ctx context.Context, modelName, mode, taskType string) (types.Session, error) {
+	var session types.Session
+	query := url.Values{
+		"model_name": {modelName},
+		"mode":       {mode},
+		"type":       {taskType},
+	}
+	path := fmt.Sprintf("/runner/%s/nextsession?%s", r.runnerID, query.Encode())
+	err := r.client.do(ctx, "GET", path, nil, &session)
+	return session, err
+}
+
+func (r *runnerClient) Respond(ctx context.Context, sessionID string, response interface{}) error {
+	return r.client.do(ctx, "POST", fmt.Sprintf("/runner/%s/response", r.runnerID), response, nil)
+}
+
+func (r *runnerClient) Download(ctx context.Context, sessionID, path string) (io.ReadCloser, error) {
+	query := url.Values{"path": {path}}
+	return r.client.stream(ctx, "GET", fmt.Sprintf("/runner/%s/session/%s/download?%s", r.runnerID, sessionID, query.Encode()))
+}
+
+func (r *runnerClient) Upload(ctx context.Context, sessionID, filename string, content io.Reader, size int64) (Operation, error) {
+	createPath := fmt.Sprintf("/runner/%s/session/%s/upload", r.runnerID, sessionID)
+	location, err := r.client.createTusUpload(ctx, createPath, size, map[string]string{"filename": filename})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.client.uploadWithResume(ctx, location, content, size); err != nil {
+		return nil, err
+	}
+	return newUploadOperation(size), nil
+}
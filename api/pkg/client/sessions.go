@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bacalhau-project/lilysaas/api/pkg/types"
+)
+
+// SessionEvent is a session-scoped message delivered over Watch, mirroring
+// what HelixAPIServer publishes on /ws.
+type SessionEvent struct {
+	SessionID string      `json:"session_id"`
+	Payload   interface{} `json:"payload"`
+}
+
+// SessionClient is the typed equivalent of the /sessions routes.
+type SessionClient interface {
+	List(ctx context.Context) ([]types.Session, error)
+	Get(ctx context.Context, id string) (types.Session, error)
+	Create(ctx context.Context, session types.Session) (types.Session, Operation, error)
+	Update(ctx context.Context, id string, session types.Session) (types.Session, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type sessionClient struct {
+	client *client
+}
+
+func (s *sessionClient) List(ctx context.Context) ([]types.Session, error) {
+	var sessions []types.Session
+	err := s.client.do(ctx, "GET", "/sessions", nil, &sessions)
+	return sessions, err
+}
+
+func (s *sessionClient) Get(ctx context.Context, id string) (types.Session, error) {
+	var session types.Session
+	err := s.client.do(ctx, "GET", fmt.Sprintf("/sessions/%s", id), nil, &session)
+	return session, err
+}
+
+// Create starts a session and returns an Operation the caller can Wait on or
+// watch for progress, since training/inference runs asynchronously.
+func (s *sessionClient) Create(ctx context.Context, session types.Session) (types.Session, Operation, error) {
+	var created types.Session
+	if err := s.client.do(ctx, "POST", "/sessions", session, &created); err != nil {
+		return types.Session{}, nil, err
+	}
+	return created, newOperation(s.client, created.ID), nil
+}
+
+func (s *sessionClient) Update(ctx context.Context, id string, session types.Session) (types.Session, error) {
+	var updated types.Session
+	err := s.client.do(ctx, "PUT", fmt.Sprintf("/sessions/%s", id), session, &updated)
+	return updated, err
+}
+
+func (s *sessionClient) Delete(ctx context.Context, id string) error {
+	return s.client.do(ctx, "DELETE", fmt.Sprintf("/sessions/%s", id), nil, nil)
+}
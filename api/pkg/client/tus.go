@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lukemarsden/helix/api/pkg/progress"
+)
+
+// createTusUpload creates a resumable upload at path (e.g. "/filestore/upload")
+// and returns the Location of the created upload resource to PATCH chunks to,
+// with the apiPrefix the server includes in it trimmed off, since every
+// request this package makes already goes to baseURL (which ends in it).
+func (c *client) createTusUpload(ctx context.Context, path string, size int64, metadata map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", c.authHeader)
+	req.Header.Set("Upload-Length", strconv.FormatInt(size, 10))
+	req.Header.Set("Upload-Metadata", encodeUploadMetadata(metadata))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("create upload: %s: %s", resp.Status, string(body))
+	}
+
+	return strings.TrimPrefix(resp.Header.Get("Location"), apiPrefix), nil
+}
+
+// patchTusUpload streams content to location in a single PATCH, starting
+// from whatever offset a prior, interrupted PATCH left off at (by issuing a
+// HEAD first), so a retried PATCH against the same location resumes rather
+// than restarting. Resuming from a non-zero offset requires content to be an
+// io.Seeker - without one there's no way to skip the bytes the server
+// already has, and sending from the reader's current position while
+// claiming Upload-Offset: offset would silently corrupt the upload.
+func (c *client) patchTusUpload(ctx context.Context, location string, content io.Reader, size int64) error {
+	offset, err := c.headTusUpload(ctx, location)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		seeker, ok := content.(io.Seeker)
+		if !ok {
+			return fmt.Errorf("patch upload: upload already has %d bytes but content cannot be seeked to resume from there", offset)
+		}
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PATCH", c.baseURL+location, io.LimitReader(content, size-offset))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.authHeader)
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("patch upload: %s: %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+// uploadPatchRetries bounds how many times uploadWithResume retries a PATCH
+// against the same tus upload after a dropped connection, before giving up.
+const uploadPatchRetries = 3
+
+// uploadWithResume drives patchTusUpload to completion against a single tus
+// upload, retrying in place against the same location on failure instead of
+// the caller creating a brand new upload (and losing whatever the server
+// already staged) on every retry. Retrying requires content to be an
+// io.Seeker: patchTusUpload only rewinds to the offset the server reports,
+// and a non-seekable reader's cursor has already moved past whatever a
+// failed attempt managed to stream, so a second attempt there would resume
+// from the wrong bytes without any way to detect it.
+func (c *client) uploadWithResume(ctx context.Context, location string, content io.Reader, size int64) error {
+	_, seekable := content.(io.Seeker)
+
+	var err error
+	for attempt := 0; attempt <= uploadPatchRetries; attempt++ {
+		err = c.patchTusUpload(ctx, location, content, size)
+		if err == nil {
+			return nil
+		}
+		if !seekable || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (c *client) headTusUpload(ctx context.Context, location string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", c.baseURL+location, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", c.authHeader)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("head upload: %s: %s", resp.Status, string(body))
+	}
+
+	offset := resp.Header.Get("Upload-Offset")
+	if offset == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(offset, 10, 64)
+}
+
+func encodeUploadMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// uploadOperation is a degenerate Operation for a synchronous tus upload:
+// by the time it's constructed the upload has already finished, so Wait
+// returns immediately and Progress carries a single "finished" frame.
+type uploadOperation struct {
+	size   int64
+	events chan progress.Event
+}
+
+func newUploadOperation(size int64) *uploadOperation {
+	op := &uploadOperation{size: size, events: make(chan progress.Event, 1)}
+	op.events <- progress.Event{Status: "finished"}
+	close(op.events)
+	return op
+}
+
+func (op *uploadOperation) Wait(ctx context.Context) error { return nil }
+func (op *uploadOperation) Cancel(ctx context.Context) error {
+	return fmt.Errorf("upload already completed")
+}
+func (op *uploadOperation) Progress() <-chan progress.Event { return op.events }
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// watchBackoffCeiling bounds how long Watch waits between reconnect attempts after
+// the websocket drops, growing exponentially up to this ceiling.
+const watchBackoffCeiling = time.Second * 30
+
+// Watch transparently manages the /ws websocket, forwarding SessionEvents to
+// the returned channel and reconnecting with backoff if the connection
+// drops, until ctx is cancelled.
+func (c *client) Watch(ctx context.Context) (<-chan SessionEvent, error) {
+	events := make(chan SessionEvent)
+
+	go func() {
+		defer close(events)
+
+		backoff := time.Second
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err := c.watchOnce(ctx, events); err != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				backoff *= 2
+				if backoff > watchBackoffCeiling {
+					backoff = watchBackoffCeiling
+				}
+				continue
+			}
+
+			backoff = time.Second
+		}
+	}()
+
+	return events, nil
+}
+
+func (c *client) watchOnce(ctx context.Context, events chan<- SessionEvent) error {
+	wsURL := "ws" + strings.TrimPrefix(c.baseURL, "http") + "/ws"
+
+	header := http.Header{}
+	header.Set("Authorization", c.authHeader)
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, header)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	for {
+		var event SessionEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			return err
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
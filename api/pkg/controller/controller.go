@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/lukemarsden/helix/api/pkg/progress"
+)
+
+// Controller owns the state shared across the API server: the model
+// registry, the channel used to tell connected websocket clients to
+// reconnect elsewhere during a drain, and the per-session progress streams
+// that back /sessions/{id}/events.
+type Controller struct {
+	// Models is the set of models the controller knows how to run, see
+	// registry.go
+	Models *ModelRegistry
+
+	// SessionUpdatesChan fans out server lifecycle notices (e.g. "server
+	// draining") to connected websocket clients
+	SessionUpdatesChan chan string
+
+	sessionsMu sync.RWMutex
+	sessions   map[string]*progress.Writer
+}
+
+// NewController creates an empty Controller. Models is left nil - callers
+// populate it via RegisterBuiltinModels/LoadModelRegistryFile, see
+// HelixAPIServer.loadModelRegistry.
+func NewController() *Controller {
+	return &Controller{
+		SessionUpdatesChan: make(chan string),
+		sessions:           map[string]*progress.Writer{},
+	}
+}
+
+// RunSession looks up spec's Handler, runs it, and registers the
+// progress.Writer it produces under sessionID so SessionEvents/
+// PublishSessionEvent can find it. The registration is removed once the
+// writer delivers its terminal event and is closed, so the registry doesn't
+// grow unbounded across the life of the server.
+func (c *Controller) RunSession(ctx context.Context, sessionID string, spec ModelSpec) (*progress.Writer, error) {
+	debugStream := make(chan string)
+	outputStream := make(chan string)
+
+	writer, err := spec.Handler(ctx, debugStream, outputStream)
+	if err != nil {
+		return nil, err
+	}
+
+	c.RegisterSession(sessionID, writer)
+	go func() {
+		// wait on Done rather than draining Events: the HTTP handler behind
+		// SessionEvents is the real (and only) consumer of those, and a
+		// second reader here would race it for every event
+		<-writer.Done()
+		c.UnregisterSession(sessionID)
+	}()
+
+	return writer, nil
+}
+
+// RegisterSession makes writer's events reachable by SessionEvents under
+// sessionID. Call once per session, as soon as the task that owns writer has
+// started.
+func (c *Controller) RegisterSession(sessionID string, writer *progress.Writer) {
+	c.sessionsMu.Lock()
+	defer c.sessionsMu.Unlock()
+	c.sessions[sessionID] = writer
+}
+
+// UnregisterSession drops sessionID's registered writer.
+func (c *Controller) UnregisterSession(sessionID string) {
+	c.sessionsMu.Lock()
+	defer c.sessionsMu.Unlock()
+	delete(c.sessions, sessionID)
+}
+
+// SessionEvents returns sessionID's registered event stream, for
+// streamSessionEvents to relay over /sessions/{id}/events.
+func (c *Controller) SessionEvents(sessionID string) (<-chan progress.Event, error) {
+	c.sessionsMu.RLock()
+	defer c.sessionsMu.RUnlock()
+	writer, ok := c.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("no active session %s", sessionID)
+	}
+	return writer.Events(), nil
+}
+
+// PublishSessionEvent re-publishes an event a runner posted for sessionID
+// (see runnerSessionEvents) onto that session's own event stream.
+func (c *Controller) PublishSessionEvent(sessionID string, event progress.Event) error {
+	c.sessionsMu.RLock()
+	writer, ok := c.sessions[sessionID]
+	c.sessionsMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no active session %s", sessionID)
+	}
+	writer.Publish(event)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/bacalhau-project/lilysaas/api/pkg/types"
+	"github.com/lukemarsden/helix/api/pkg/progress"
 )
 
 type TextToImage struct {
@@ -15,11 +16,21 @@ type TextToImage struct {
 	OutputStream chan string
 	Status       string   `json:"status"`        // running, finished, error
 	ResultImages []string `json:"result_images"` // filenames relative to OutputPath, only expect this to be filled in when Status == finished
+	// Progress reports step/progress events for the generation, terminating in a finished event carrying ResultImages
+	Progress *progress.Writer `json:"-"`
 }
 
 // base as opposed to refiner
-func (t2i *TextToImage) SDXL_1_0_Base(ctx context.Context) error {
-	return nil
+func (t2i *TextToImage) SDXL_1_0_Base(ctx context.Context) (*progress.Writer, error) {
+	t2i.Progress = progress.NewWriter()
+	go func() {
+		t2i.Progress.Pump(ctx, t2i.DebugStream, t2i.OutputStream)
+		if !t2i.Progress.Failed() {
+			t2i.Progress.Finished(t2i.ResultImages, "")
+		}
+		t2i.Progress.Close()
+	}()
+	return t2i.Progress, nil
 }
 
 type LanguageModel struct {
@@ -29,10 +40,20 @@ type LanguageModel struct {
 	DebugStream  chan string
 	OutputStream chan string // NB PYTHONUNBUFFERED=1
 	Status       string      `json:"status"` // running, finished, error
+	// Progress reports the streamed inference tokens, terminating in a finished event once generation completes
+	Progress *progress.Writer `json:"-"`
 }
 
-func (l *LanguageModel) Mistral_7B_Instruct_v0_1(ctx context.Context) error {
-	return nil
+func (l *LanguageModel) Mistral_7B_Instruct_v0_1(ctx context.Context) (*progress.Writer, error) {
+	l.Progress = progress.NewWriter()
+	go func() {
+		l.Progress.Pump(ctx, l.DebugStream, l.OutputStream)
+		if !l.Progress.Failed() {
+			l.Progress.Finished(nil, "")
+		}
+		l.Progress.Close()
+	}()
+	return l.Progress, nil
 }
 
 type FinetuneTextToImage struct {
@@ -44,10 +65,21 @@ type FinetuneTextToImage struct {
 	OutputStream chan string
 	Status       string `json:"status"`      // running, finished, error
 	OutputFile   string `json:"output_file"` // a specific e.g. LoRA filename within that directory
+	// Progress reports per-epoch training progress, terminating in a finished event carrying OutputFile
+	Progress *progress.Writer `json:"-"`
 }
 
-func (f *FinetuneTextToImage) SDXL_1_0_Base_Finetune(ctx context.Context) error {
-	return nil
+// base as opposed to refiner
+func (f *FinetuneTextToImage) SDXL_1_0_Base_Finetune(ctx context.Context) (*progress.Writer, error) {
+	f.Progress = progress.NewWriter()
+	go func() {
+		f.Progress.Pump(ctx, f.DebugStream, f.OutputStream)
+		if !f.Progress.Failed() {
+			f.Progress.Finished(nil, f.OutputFile)
+		}
+		f.Progress.Close()
+	}()
+	return f.Progress, nil
 }
 
 type FinetuneLanguageModel struct {
@@ -59,6 +91,20 @@ type FinetuneLanguageModel struct {
 	OutputStream chan string
 	Status       string `json:"status"`      // running, finished, error
 	OutputFile   string `json:"output_file"` // a specific e.g. LoRA filename within the given output directory
+	// Progress reports axolotl's per-step training progress, terminating in a finished event carrying OutputFile
+	Progress *progress.Writer `json:"-"`
+}
+
+func (f *FinetuneLanguageModel) Mistral_7B_Instruct_v0_1_Finetune(ctx context.Context) (*progress.Writer, error) {
+	f.Progress = progress.NewWriter()
+	go func() {
+		f.Progress.Pump(ctx, f.DebugStream, f.OutputStream)
+		if !f.Progress.Failed() {
+			f.Progress.Finished(nil, f.OutputFile)
+		}
+		f.Progress.Close()
+	}()
+	return f.Progress, nil
 }
 
 type ShareGPT struct {
@@ -70,4 +116,4 @@ type ShareGPT struct {
 
 func (f *FinetuneTextToImage) Mistral_7B_Instruct_v0_1(ctx context.Context) error {
 	return nil
-}
\ No newline at end of file
+}
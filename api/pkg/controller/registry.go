@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/lukemarsden/helix/api/pkg/progress"
+)
+
+// Modality names the kind of task a ModelSpec's Handler performs, mirroring
+// the controller's existing task types (TextToImage, LanguageModel, ...).
+type Modality string
+
+const (
+	ModalityTextToImage         Modality = "text-to-image"
+	ModalityLanguage            Modality = "language"
+	ModalityFinetuneTextToImage Modality = "finetune-text-to-image"
+	ModalityFinetuneLanguage    Modality = "finetune-language"
+)
+
+// ResourceRequirements describes what a runner needs in order to run a
+// ModelSpec, so /runner/{runnerid}/nextsession can filter the registry down
+// to models the polling runner is actually able to run.
+type ResourceRequirements struct {
+	GPUClass string `json:"gpu_class" yaml:"gpu_class"`
+	VRAMGB   int    `json:"vram_gb" yaml:"vram_gb"`
+}
+
+// Handler runs a model against the existing DebugStream/OutputStream
+// channels, the same contract TextToImage.SDXL_1_0_Base and friends already
+// follow, just reachable by name instead of a hard-coded method receiver. It
+// returns the progress.Writer the task created, so Controller.RunSession can
+// register it under the session's ID for /sessions/{id}/events.
+type Handler func(ctx context.Context, debugStream, outputStream chan string) (*progress.Writer, error)
+
+// ModelSpec declares everything the controller needs to run and describe a
+// model, so adding one doesn't require a new method receiver and recompile.
+// Handler is nil for specs loaded from models.yaml, so those cannot be run
+// in-process by Controller.RunSession; runners are matched to them by Name
+// (see ModelRegistry.Capable), and nothing in the server reads RunnerImage.
+type ModelSpec struct {
+	Name                 string                 `json:"name" yaml:"name"`
+	Modality             Modality               `json:"modality" yaml:"modality"`
+	RunnerImage          string                 `json:"runner_image" yaml:"runner_image"`
+	InputSchema          map[string]interface{} `json:"input_schema" yaml:"input_schema"`
+	OutputSchema         map[string]interface{} `json:"output_schema" yaml:"output_schema"`
+	ResourceRequirements ResourceRequirements   `json:"resource_requirements" yaml:"resource_requirements"`
+	Handler              Handler                `json:"-" yaml:"-"`
+}
+
+// ModelRegistry is the set of models the controller knows how to run,
+// populated at startup from models.yaml (LoadModelRegistryFile) plus
+// whatever RegisterBuiltinModels registers for the existing hard-coded ones.
+type ModelRegistry struct {
+	mu     sync.RWMutex
+	models map[string]ModelSpec
+}
+
+func NewModelRegistry() *ModelRegistry {
+	return &ModelRegistry{
+		models: map[string]ModelSpec{},
+	}
+}
+
+// Register adds or replaces spec in the registry.
+func (r *ModelRegistry) Register(spec ModelSpec) error {
+	if spec.Name == "" {
+		return fmt.Errorf("model spec must have a name")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.models[spec.Name] = spec
+	return nil
+}
+
+// Get looks up a single ModelSpec by name.
+func (r *ModelRegistry) Get(name string) (ModelSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	spec, ok := r.models[name]
+	return spec, ok
+}
+
+// List returns every registered ModelSpec, for GET /models to render
+// dynamic forms from InputSchema/OutputSchema.
+func (r *ModelRegistry) List() []ModelSpec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	specs := make([]ModelSpec, 0, len(r.models))
+	for _, spec := range r.models {
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// Capable filters the registry down to models of the given modality that fit
+// within a runner's advertised GPU class and VRAM, for
+// /runner/{runnerid}/nextsession to pick from.
+func (r *ModelRegistry) Capable(modality Modality, gpuClass string, vramGB int) []ModelSpec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var capable []ModelSpec
+	for _, spec := range r.models {
+		if spec.Modality != modality {
+			continue
+		}
+		if spec.ResourceRequirements.VRAMGB > vramGB {
+			continue
+		}
+		if spec.ResourceRequirements.GPUClass != "" && spec.ResourceRequirements.GPUClass != gpuClass {
+			continue
+		}
+		capable = append(capable, spec)
+	}
+	return capable
+}
+
+// RegisterBuiltinModels registers the model/method pairs that predate the
+// registry, so existing sessions keep working unchanged while new models can
+// be added via models.yaml instead of a new Go method receiver.
+func RegisterBuiltinModels(registry *ModelRegistry) error {
+	builtins := []ModelSpec{
+		{
+			Name:     "SDXL_1_0_Base",
+			Modality: ModalityTextToImage,
+			Handler: func(ctx context.Context, debugStream, outputStream chan string) (*progress.Writer, error) {
+				t2i := &TextToImage{DebugStream: debugStream, OutputStream: outputStream}
+				return t2i.SDXL_1_0_Base(ctx)
+			},
+		},
+		{
+			Name:     "Mistral_7B_Instruct_v0_1",
+			Modality: ModalityLanguage,
+			Handler: func(ctx context.Context, debugStream, outputStream chan string) (*progress.Writer, error) {
+				l := &LanguageModel{DebugStream: debugStream, OutputStream: outputStream}
+				return l.Mistral_7B_Instruct_v0_1(ctx)
+			},
+		},
+		{
+			Name:     "SDXL_1_0_Base_Finetune",
+			Modality: ModalityFinetuneTextToImage,
+			Handler: func(ctx context.Context, debugStream, outputStream chan string) (*progress.Writer, error) {
+				f := &FinetuneTextToImage{DebugStream: debugStream, OutputStream: outputStream}
+				return f.SDXL_1_0_Base_Finetune(ctx)
+			},
+		},
+		{
+			Name:     "Mistral_7B_Instruct_v0_1_Finetune",
+			Modality: ModalityFinetuneLanguage,
+			Handler: func(ctx context.Context, debugStream, outputStream chan string) (*progress.Writer, error) {
+				f := &FinetuneLanguageModel{DebugStream: debugStream, OutputStream: outputStream}
+				return f.Mistral_7B_Instruct_v0_1_Finetune(ctx)
+			},
+		},
+	}
+
+	for _, spec := range builtins {
+		if err := registry.Register(spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
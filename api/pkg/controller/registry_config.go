@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// modelsConfigFile is the on-disk shape of models.yaml: a flat list of specs,
+// so operators can add a model by adding an entry to that file rather than
+// editing Go source. Only the one file passed to LoadModelRegistryFile is
+// read; separate per-model spec files are not picked up.
+type modelsConfigFile struct {
+	Models []ModelSpec `yaml:"models"`
+}
+
+// LoadModelRegistryFile reads path (conventionally models.yaml) and
+// registers every spec it declares into registry.
+func LoadModelRegistryFile(registry *ModelRegistry, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var config modelsConfigFile
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return err
+	}
+
+	for _, spec := range config.Models {
+		if err := registry.Register(spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+// Package progress defines the wire format used to stream live status from
+// training and inference jobs back to clients, modelled on the newline
+// delimited JSON events emitted by `docker build`/`docker pull`.
+package progress
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ProgressDetail carries the current/total counters for a download or a
+// per-epoch training step, e.g. {"current": 3, "total": 10} for epoch 3/10.
+type ProgressDetail struct {
+	Current int64 `json:"current"`
+	Total   int64 `json:"total"`
+}
+
+// ErrorDetail mirrors Docker's errorDetail object so existing ND-JSON
+// consumers (e.g. a future CLI client) don't need a Helix-specific error path.
+type ErrorDetail struct {
+	Message string `json:"message"`
+}
+
+// Event is a single ND-JSON line written to a /sessions/{id}/events stream.
+// Exactly one of Stream, ProgressDetail or ErrorDetail is normally populated
+// alongside Status, matching the Docker build/pull convention.
+type Event struct {
+	Status         string          `json:"status"`
+	ID             string          `json:"id,omitempty"`
+	ProgressDetail *ProgressDetail `json:"progressDetail,omitempty"`
+	Stream         string          `json:"stream,omitempty"`
+	ErrorDetail    *ErrorDetail    `json:"errorDetail,omitempty"`
+	// ResultImages and OutputFile are only set on the terminal "finished" event.
+	ResultImages []string `json:"result_images,omitempty"`
+	OutputFile   string   `json:"output_file,omitempty"`
+}
+
+// Writer multiplexes the raw DebugStream/OutputStream string channels used
+// by the controller task types into typed Events that can be marshalled
+// directly onto the wire.
+type Writer struct {
+	events chan Event
+	closed chan struct{}
+	step   int
+	failed bool
+}
+
+// NewWriter creates a Writer with a reasonably sized buffer so a slow HTTP
+// client doesn't block the training/inference goroutine.
+func NewWriter() *Writer {
+	return &Writer{
+		events: make(chan Event, 256),
+		closed: make(chan struct{}),
+	}
+}
+
+// Events returns the channel of Events produced by this Writer. It is closed
+// once Close is called. There should only ever be one reader of this channel
+// - Done exists precisely so a second party (e.g. Controller.RunSession,
+// cleaning up its registry entry) doesn't have to also read from it and race
+// the real consumer for events.
+func (w *Writer) Events() <-chan Event {
+	return w.events
+}
+
+// Done returns a channel that closes once Close has been called, for a
+// caller that needs to know the Writer is finished without competing with
+// Events' consumer for the events themselves.
+func (w *Writer) Done() <-chan struct{} {
+	return w.closed
+}
+
+func (w *Writer) nextStepID() string {
+	w.step++
+	return "step-" + strconv.Itoa(w.step)
+}
+
+// Status emits a phase-change event, e.g. "loading base model" or "epoch 3/10".
+func (w *Writer) Status(status string) {
+	w.events <- Event{
+		Status: status,
+		ID:     w.nextStepID(),
+	}
+}
+
+// Progress emits a numeric progress event for a download or training step.
+func (w *Writer) Progress(status string, current, total int64) {
+	w.events <- Event{
+		Status:         status,
+		ID:             w.nextStepID(),
+		ProgressDetail: &ProgressDetail{Current: current, Total: total},
+	}
+}
+
+// Stream emits a raw line of stdout/stderr, unchanged.
+func (w *Writer) Stream(line string) {
+	w.events <- Event{
+		Stream: line,
+	}
+}
+
+// Error emits a terminal error frame. The caller should stop calling the
+// other methods and then Close the Writer.
+func (w *Writer) Error(err error) {
+	w.failed = true
+	w.events <- Event{
+		Status:      "error",
+		ErrorDetail: &ErrorDetail{Message: err.Error()},
+	}
+}
+
+// Failed reports whether Error has already been emitted, so a caller
+// draining Pump to completion knows not to also emit a Finished frame.
+func (w *Writer) Failed() bool {
+	return w.failed
+}
+
+// Publish emits event as-is, for republishing an event a runner posted over
+// its own ND-JSON stream (see runnerSessionEvents) onto this Writer's
+// stream.
+func (w *Writer) Publish(event Event) {
+	w.events <- event
+}
+
+// Finished emits a terminal frame carrying whichever of resultImages or
+// outputFile applies to the task type that finished.
+func (w *Writer) Finished(resultImages []string, outputFile string) {
+	w.events <- Event{
+		Status:       "finished",
+		ResultImages: resultImages,
+		OutputFile:   outputFile,
+	}
+}
+
+// Close signals that no more Events will be produced. Callers must call this
+// exactly once, after any terminal Error/Finished event.
+func (w *Writer) Close() {
+	close(w.events)
+	close(w.closed)
+}
+
+// Pump reads lines from the existing DebugStream/OutputStream channels and
+// classifies them into status/progress/stream/error Events until both
+// channels are closed or ctx is cancelled. Lines are classified by a
+// "status:", "progress: current/total" or "error:" prefix (case
+// insensitive); anything else is forwarded as a raw stream line. Pump itself
+// never returns an error - a task signals its own failure by writing an
+// "error: ..." line, which Pump turns into a terminal Error event, observable
+// by callers via Failed() once Pump returns.
+func (w *Writer) Pump(ctx context.Context, debugStream, outputStream <-chan string) {
+	for debugStream != nil || outputStream != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case line, ok := <-debugStream:
+			if !ok {
+				debugStream = nil
+				continue
+			}
+			w.classify(line)
+		case line, ok := <-outputStream:
+			if !ok {
+				outputStream = nil
+				continue
+			}
+			w.classify(line)
+		}
+	}
+}
+
+func (w *Writer) classify(line string) {
+	scanner := bufio.NewScanner(strings.NewReader(line))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		w.classifyLine(scanner.Text())
+	}
+}
+
+func (w *Writer) classifyLine(line string) {
+	switch {
+	case strings.HasPrefix(strings.ToLower(line), "error:"):
+		w.Error(errors.New(strings.TrimSpace(line[len("error:"):])))
+	case strings.HasPrefix(strings.ToLower(line), "status:"):
+		w.Status(strings.TrimSpace(line[len("status:"):]))
+	case strings.HasPrefix(strings.ToLower(line), "progress:"):
+		current, total, ok := parseProgress(line[len("progress:"):])
+		if !ok {
+			w.Stream(line)
+			return
+		}
+		w.Progress("downloading", current, total)
+	default:
+		w.Stream(line)
+	}
+}
+
+// parseProgress parses "current/total", e.g. " 120/512" -> (120, 512, true).
+func parseProgress(s string) (int64, int64, bool) {
+	parts := strings.SplitN(strings.TrimSpace(s), "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	current, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	total, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return current, total, true
+}
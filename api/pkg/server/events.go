@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lukemarsden/helix/api/pkg/progress"
+)
+
+// streamSessionEvents pushes the session's progress.Event stream to the
+// client as newline-delimited JSON, flushing after every line so tools like
+// `curl -N` and websocket-less clients render live progress bars.
+func (apiServer *HelixAPIServer) streamSessionEvents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	events, err := apiServer.Controller.SessionEvents(sessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	ctx := r.Context()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// runnerSessionEvents accepts the runner's own ND-JSON progress stream for a
+// session and republishes each line onto the session's event stream so that
+// /sessions/{id}/events subscribers see it.
+func (apiServer *HelixAPIServer) runnerSessionEvents(res http.ResponseWriter, req *http.Request) (string, error) {
+	vars := mux.Vars(req)
+	sessionID := vars["sessionid"]
+
+	decoder := json.NewDecoder(req.Body)
+	for {
+		var event progress.Event
+		if err := decoder.Decode(&event); err != nil {
+			break
+		}
+		if err := apiServer.Controller.PublishSessionEvent(sessionID, event); err != nil {
+			return "", err
+		}
+	}
+
+	return "ok", nil
+}
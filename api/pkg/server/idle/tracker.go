@@ -0,0 +1,151 @@
+// Package idle tracks live HTTP connections so the server can shut down
+// gracefully once it has gone quiet, instead of an interrupt killing
+// in-flight uploads, long-poll runner requests and websocket sessions.
+package idle
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Tracker wraps http.Server.ConnState and, once a shutdown has been
+// requested via RequestShutdown, signals Done once there have been no
+// active, non-hijacked connections for the configured idle window. This is
+// the same approach Podman's API service uses to decide when it is safe to
+// exit after systemd socket activation.
+type Tracker struct {
+	idleTimeout time.Duration
+
+	mu           sync.Mutex
+	active       int
+	hijacked     map[net.Conn]struct{}
+	idle         map[net.Conn]struct{}
+	lastActivity time.Time
+
+	shuttingDown bool
+	done         chan struct{}
+	doneSet      bool
+	timer        *time.Timer
+}
+
+// NewTracker creates a Tracker for idleTimeout. The idle countdown does not
+// start until RequestShutdown is called, so a quiet server doesn't trip Done
+// during normal operation.
+func NewTracker(idleTimeout time.Duration) *Tracker {
+	return &Tracker{
+		idleTimeout:  idleTimeout,
+		hijacked:     map[net.Conn]struct{}{},
+		idle:         map[net.Conn]struct{}{},
+		lastActivity: time.Now(),
+		done:         make(chan struct{}),
+	}
+}
+
+// RequestShutdown begins the idle countdown: Done closes once there are zero
+// active, non-hijacked connections for idleTimeout, measured from now (or
+// from whenever that next becomes true).
+func (t *Tracker) RequestShutdown() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.shuttingDown {
+		return
+	}
+	t.shuttingDown = true
+	t.maybeArmTimerLocked()
+}
+
+// ConnState should be installed as http.Server.ConnState.
+func (t *Tracker) ConnState(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastActivity = time.Now()
+
+	switch state {
+	case http.StateNew:
+		t.active++
+		t.resetTimerLocked()
+	case http.StateActive:
+		// a connection is already counted from StateNew until it goes idle,
+		// so it is only counted again when it comes back from StateIdle
+		if _, ok := t.idle[conn]; ok {
+			delete(t.idle, conn)
+			t.active++
+		}
+		t.resetTimerLocked()
+	case http.StateIdle:
+		t.active--
+		t.idle[conn] = struct{}{}
+		t.maybeArmTimerLocked()
+	case http.StateHijacked:
+		// hijacked connections (e.g. websockets) don't hold up shutdown; the
+		// long-poll runner routes are not hijacked and count as active
+		t.active--
+		t.hijacked[conn] = struct{}{}
+		t.maybeArmTimerLocked()
+	case http.StateClosed:
+		if _, ok := t.hijacked[conn]; ok {
+			delete(t.hijacked, conn)
+		} else if _, ok := t.idle[conn]; ok {
+			delete(t.idle, conn)
+		} else {
+			t.active--
+		}
+		t.maybeArmTimerLocked()
+	}
+}
+
+// Active returns the number of connections currently counted as active
+// (StateNew/StateActive), excluding hijacked connections.
+func (t *Tracker) Active() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.active < 0 {
+		return 0
+	}
+	return t.active
+}
+
+// LastActivity returns the time of the most recent ConnState transition.
+func (t *Tracker) LastActivity() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lastActivity
+}
+
+// Done returns a channel that is closed once RequestShutdown has been called
+// and the server has since been idle (zero active, non-hijacked connections)
+// for the configured idle window. It never fires before RequestShutdown.
+func (t *Tracker) Done() <-chan struct{} {
+	return t.done
+}
+
+func (t *Tracker) resetTimerLocked() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+func (t *Tracker) maybeArmTimerLocked() {
+	if !t.shuttingDown {
+		return
+	}
+	if t.active <= 0 {
+		if t.timer != nil {
+			t.timer.Stop()
+		}
+		t.timer = time.AfterFunc(t.idleTimeout, t.fire)
+	}
+}
+
+func (t *Tracker) fire() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.active > 0 || t.doneSet {
+		return
+	}
+	t.doneSet = true
+	close(t.done)
+}
@@ -0,0 +1,52 @@
+package idle
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTrackerDoneWaitsForIdle(t *testing.T) {
+	tracker := NewTracker(10 * time.Millisecond)
+	tracker.ConnState(nil, http.StateNew)
+	tracker.RequestShutdown()
+
+	select {
+	case <-tracker.Done():
+		t.Fatal("Done fired while a connection was still active")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	tracker.ConnState(nil, http.StateClosed)
+
+	select {
+	case <-tracker.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done did not fire after the active connection closed")
+	}
+}
+
+func TestTrackerDoneIgnoresHijackedConnections(t *testing.T) {
+	tracker := NewTracker(10 * time.Millisecond)
+	tracker.ConnState(nil, http.StateNew)
+	tracker.ConnState(nil, http.StateHijacked)
+	tracker.RequestShutdown()
+
+	select {
+	case <-tracker.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done did not fire while only a hijacked connection remained open")
+	}
+}
+
+func TestTrackerDoneNeverFiresBeforeShutdownRequested(t *testing.T) {
+	tracker := NewTracker(10 * time.Millisecond)
+	tracker.ConnState(nil, http.StateNew)
+	tracker.ConnState(nil, http.StateClosed)
+
+	select {
+	case <-tracker.Done():
+		t.Fatal("Done fired before RequestShutdown was called")
+	case <-time.After(30 * time.Millisecond):
+	}
+}
@@ -0,0 +1,14 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/lukemarsden/helix/api/pkg/controller"
+)
+
+// getModels returns the controller's ModelRegistry so UIs can render dynamic
+// forms from each ModelSpec's InputSchema/OutputSchema rather than
+// hard-coding a form per model.
+func (apiServer *HelixAPIServer) getModels(r *http.Request) ([]controller.ModelSpec, error) {
+	return apiServer.Controller.Models.List(), nil
+}
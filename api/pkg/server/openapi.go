@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// openAPIPathItem is a minimal OpenAPI 3.0 Path Item: just enough for non-Go
+// bindings to discover the route table, without hand-maintaining it
+// alongside ListenAndServe.
+type openAPIPathItem map[string]openAPIOperation
+
+type openAPIOperation struct {
+	OperationID string `json:"operationId"`
+}
+
+type openAPISchema struct {
+	OpenAPI string                     `json:"openapi"`
+	Info    map[string]string          `json:"info"`
+	Paths   map[string]openAPIPathItem `json:"paths"`
+}
+
+// generateOpenAPISchema walks router's registered routes and produces a
+// minimal OpenAPI document, so language bindings beyond Go can be generated
+// from the same route table ListenAndServe defines, instead of drifting from
+// it.
+func generateOpenAPISchema(router *mux.Router) (openAPISchema, error) {
+	schema := openAPISchema{
+		OpenAPI: "3.0.3",
+		Info: map[string]string{
+			"title":   "Helix API",
+			"version": "1.0.0",
+		},
+		Paths: map[string]openAPIPathItem{},
+	}
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		pathTemplate, err := route.GetPathTemplate()
+		if err != nil {
+			// routes without a path matcher don't have a fixed template to
+			// document
+			return nil
+		}
+		// routes without a method matcher (e.g. the filestore viewer
+		// PathPrefix) are skipped too
+		methods, err := route.GetMethods()
+		if err != nil || len(methods) == 0 {
+			return nil
+		}
+
+		item, ok := schema.Paths[pathTemplate]
+		if !ok {
+			item = openAPIPathItem{}
+			schema.Paths[pathTemplate] = item
+		}
+		for _, method := range methods {
+			item[method] = openAPIOperation{OperationID: route.GetName()}
+		}
+		return nil
+	})
+	if err != nil {
+		return openAPISchema{}, err
+	}
+
+	return schema, nil
+}
+
+// openAPI serves the generated schema at GET /api/v1/openapi.json.
+func (apiServer *HelixAPIServer) openAPI(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		schema, err := generateOpenAPISchema(router)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(schema)
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lukemarsden/helix/api/pkg/server/runnertoken"
+)
+
+// runnerArtifactURLTTL bounds how long a pre-signed download/upload URL
+// handed to a runner in a session payload stays valid for.
+const runnerArtifactURLTTL = time.Hour
+
+// runnerPollTokenTTL bounds how long a /{runnerid}/register bootstrap token
+// is good for before the runner has to register again.
+const runnerPollTokenTTL = time.Minute * 10
+
+// signRunnerArtifactURL mints a token-bound download/upload URL for a single
+// input or result artifact, so getNextRunnerSession can embed pre-signed
+// URLs in place of raw filestore paths.
+func (apiServer *HelixAPIServer) signRunnerArtifactURL(runnerID, sessionID, path, route string) (string, error) {
+	token, err := apiServer.RunnerTokens.Mint(runnerID, sessionID, path, runnerArtifactURLTTL)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%s/runner/%s/session/%s/%s?path=%s&token=%s", apiServer.Options.URL, API_PREFIX, runnerID, sessionID, route, url.QueryEscape(path), token), nil
+}
+
+// registerRunnerResponse carries the bootstrap token a runner exchanges for
+// its first authenticated /{runnerid}/nextsession poll.
+type registerRunnerResponse struct {
+	Token string `json:"token"`
+}
+
+// registerRunner mints the very first runner token for runnerID. It has to
+// sit outside runnerTokenMiddleware, since a runner that's just booted has
+// no token yet to present - /{runnerid}/nextsession can't be the first thing
+// a runner calls if every route under /runner requires one.
+//
+// The minted token is unscoped (no SessionID/Path), so it is exempt from the
+// single-use replay check (see runnertoken.Keyring.Verify) and the runner
+// keeps presenting it on every /nextsession poll until runnerPollTokenTTL
+// elapses, at which point it calls /register again for a new one.
+func (apiServer *HelixAPIServer) registerRunner(r *http.Request) (*registerRunnerResponse, error) {
+	runnerID := mux.Vars(r)["runnerid"]
+	if runnerID == "" {
+		return nil, fmt.Errorf("runnerid is required")
+	}
+	token, err := apiServer.RunnerTokens.Mint(runnerID, "", "", runnerPollTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &registerRunnerResponse{Token: token}, nil
+}
+
+// runnerTokenMiddleware verifies the signed JWT on the otherwise
+// unauthenticated runner routes. It accepts the token from either an
+// "Authorization: Bearer" header or a "?token=" query parameter, so the same
+// URL can be handed to a runner for a plain HTTP file download as well as
+// used by an API client that sets headers.
+func (apiServer *HelixAPIServer) runnerTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenString := bearerToken(r)
+		if tokenString == "" {
+			tokenString = r.URL.Query().Get("token")
+		}
+		if tokenString == "" {
+			http.Error(w, "missing runner token", http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := apiServer.RunnerTokens.Verify(tokenString)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		vars := mux.Vars(r)
+		if runnerID, ok := vars["runnerid"]; ok && claims.RunnerID != runnerID {
+			http.Error(w, "runner token does not match runnerid", http.StatusForbidden)
+			return
+		}
+		if sessionID, ok := vars["sessionid"]; ok && claims.SessionID != sessionID {
+			http.Error(w, "runner token does not match sessionid", http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), runnerClaimsContextKey, claims)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ""
+	}
+	return strings.TrimPrefix(header, "Bearer ")
+}
+
+type runnerClaimsContextKeyType struct{}
+
+var runnerClaimsContextKey = runnerClaimsContextKeyType{}
+
+// runnerClaimsFromRequest returns the verified runnertoken.Claims for a
+// request that has passed through runnerTokenMiddleware.
+func runnerClaimsFromRequest(r *http.Request) *runnertoken.Claims {
+	claims, _ := r.Context().Value(runnerClaimsContextKey).(*runnertoken.Claims)
+	return claims
+}
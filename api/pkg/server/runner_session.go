@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bacalhau-project/lilysaas/api/pkg/types"
+	"github.com/gorilla/mux"
+	"github.com/lukemarsden/helix/api/pkg/controller"
+)
+
+// nextRunnerSessionResponse carries the next queued session a runner polled
+// for, plus a pre-signed URL for its input artifact so the runner can
+// download it without its own filestore credentials.
+type nextRunnerSessionResponse struct {
+	types.Session
+	InputDownloadURL string `json:"input_download_url,omitempty"`
+}
+
+// getNextRunnerSession hands runnerID the next queued session whose model it
+// is capable of running, restricting the pick to models that fit the
+// runner's advertised GPU class/VRAM (see ModelRegistry.Capable) rather than
+// handing it a session it doesn't have the resources to run, and signs a
+// pre-signed download URL for the session's input artifact into the
+// response (see signRunnerArtifactURL) rather than handing back a raw
+// filestore path the runner has no credentials for.
+func (apiServer *HelixAPIServer) getNextRunnerSession(r *http.Request) (*nextRunnerSessionResponse, error) {
+	runnerID := mux.Vars(r)["runnerid"]
+	query := r.URL.Query()
+
+	gpuClass := query.Get("gpu_class")
+	vramGB, _ := strconv.Atoi(query.Get("vram_gb"))
+
+	capableNames := apiServer.capableModelNames(gpuClass, vramGB)
+	if len(capableNames) == 0 {
+		return nil, fmt.Errorf("no model runner %s advertised gpu_class=%q vram_gb=%d is capable of running is registered", runnerID, gpuClass, vramGB)
+	}
+
+	session, err := apiServer.Store.NextQueuedSession(r.Context(), runnerID, capableNames)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("no session queued for runner %s", runnerID)
+	}
+
+	response := &nextRunnerSessionResponse{Session: *session}
+
+	if session.InputPath != "" {
+		downloadURL, err := apiServer.signRunnerArtifactURL(runnerID, session.ID, session.InputPath, "download")
+		if err != nil {
+			return nil, err
+		}
+		response.InputDownloadURL = downloadURL
+	}
+
+	return response, nil
+}
+
+// capableModelNames returns the names of every registered model whose
+// resource requirements fit gpuClass/vramGB, across all modalities, for
+// getNextRunnerSession to restrict its queue lookup to.
+func (apiServer *HelixAPIServer) capableModelNames(gpuClass string, vramGB int) []string {
+	modalities := []controller.Modality{
+		controller.ModalityTextToImage,
+		controller.ModalityLanguage,
+		controller.ModalityFinetuneTextToImage,
+		controller.ModalityFinetuneLanguage,
+	}
+
+	var names []string
+	for _, modality := range modalities {
+		for _, spec := range apiServer.Controller.Models.Capable(modality, gpuClass, vramGB) {
+			names = append(names, spec.Name)
+		}
+	}
+	return names
+}
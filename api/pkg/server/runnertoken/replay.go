@@ -0,0 +1,52 @@
+package runnertoken
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// replayCache remembers nonces that have already been claimed, so a
+// pre-signed URL can't be replayed. Entries expire alongside the token they
+// came from, since a token can't be replayed once it no longer verifies.
+type replayCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newReplayCache() *replayCache {
+	return &replayCache{
+		seen: map[string]time.Time{},
+	}
+}
+
+// claim returns true the first time a nonce is seen, and false on every
+// subsequent call until it expires.
+func (c *replayCache) claim(nonce string, expiresAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpiredLocked()
+
+	if _, ok := c.seen[nonce]; ok {
+		return false
+	}
+	c.seen[nonce] = expiresAt
+	return true
+}
+
+func (c *replayCache) evictExpiredLocked() {
+	now := time.Now()
+	for nonce, expiresAt := range c.seen {
+		if now.After(expiresAt) {
+			delete(c.seen, nonce)
+		}
+	}
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,105 @@
+// Package runnertoken mints and verifies short-lived JWTs that authorize the
+// otherwise-unauthenticated runner endpoints (/runner/{runnerid}/nextsession,
+// /runner/{runnerid}/response and the session download/upload routes).
+package runnertoken
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims binds a token to a single runner, session and path so a leaked
+// download URL can't be replayed against a different session.
+type Claims struct {
+	RunnerID  string `json:"runnerid"`
+	SessionID string `json:"sessionid"`
+	Path      string `json:"path"`
+	Nonce     string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+var (
+	ErrExpired = errors.New("runner token expired")
+	ErrReplay  = errors.New("runner token already used")
+)
+
+// Keyring holds the set of secrets a Keyring can sign/verify with, indexed by
+// KeyID, so operators can roll a secret by adding a new key and retiring the
+// old one once its outstanding tokens have expired.
+type Keyring struct {
+	keys        map[string][]byte
+	activeKeyID string
+	replayCache *replayCache
+}
+
+// NewKeyring creates a Keyring whose active signing key is activeKeyID.
+func NewKeyring(keys map[string][]byte, activeKeyID string) *Keyring {
+	return &Keyring{
+		keys:        keys,
+		activeKeyID: activeKeyID,
+		replayCache: newReplayCache(),
+	}
+}
+
+// Mint creates a signed token bound to runnerID/sessionID/path, valid for ttl.
+func (k *Keyring) Mint(runnerID, sessionID, path string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		RunnerID:  runnerID,
+		SessionID: sessionID,
+		Path:      path,
+		Nonce:     newNonce(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = k.activeKeyID
+
+	key, ok := k.keys[k.activeKeyID]
+	if !ok {
+		return "", errors.New("no active signing key configured")
+	}
+	return token.SignedString(key)
+}
+
+// Verify parses and validates a token, checking its signature against the
+// key named by its "kid" header and rejecting expired tokens.
+//
+// Tokens scoped to a session/path (minted by signRunnerArtifactURL) are also
+// checked against a replay cache, rejecting any nonce already seen - those
+// get embedded directly in download/upload URLs, so they shouldn't be
+// replayable once used. Unscoped poll tokens (minted by registerRunner, with
+// no SessionID or Path set) skip that check: a
+// runner is expected to keep presenting the same poll token on every
+// /nextsession request until it expires, so making it single-use would
+// strand the runner after its first poll.
+func (k *Keyring) Verify(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := k.keys[kid]
+		if !ok {
+			return nil, errors.New("unknown signing key id")
+		}
+		return key, nil
+	})
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.SessionID != "" || claims.Path != "" {
+		if !k.replayCache.claim(claims.Nonce, claims.ExpiresAt.Time) {
+			return nil, ErrReplay
+		}
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,86 @@
+package runnertoken
+
+import (
+	"testing"
+	"time"
+)
+
+func testKeyring() *Keyring {
+	return NewKeyring(map[string][]byte{"k1": []byte("test-secret")}, "k1")
+}
+
+func TestMintVerifyRoundTrip(t *testing.T) {
+	k := testKeyring()
+
+	token, err := k.Mint("runner-1", "session-1", "sessions/session-1/input", time.Minute)
+	if err != nil {
+		t.Fatalf("Mint: %v", err)
+	}
+
+	claims, err := k.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.RunnerID != "runner-1" || claims.SessionID != "session-1" || claims.Path != "sessions/session-1/input" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestVerifyRejectsExpiredToken(t *testing.T) {
+	k := testKeyring()
+
+	token, err := k.Mint("runner-1", "session-1", "path", -time.Minute)
+	if err != nil {
+		t.Fatalf("Mint: %v", err)
+	}
+
+	if _, err := k.Verify(token); err != ErrExpired {
+		t.Fatalf("Verify: got %v, want ErrExpired", err)
+	}
+}
+
+func TestVerifyRejectsReplayedScopedToken(t *testing.T) {
+	k := testKeyring()
+
+	token, err := k.Mint("runner-1", "session-1", "sessions/session-1/input", time.Minute)
+	if err != nil {
+		t.Fatalf("Mint: %v", err)
+	}
+
+	if _, err := k.Verify(token); err != nil {
+		t.Fatalf("first Verify: %v", err)
+	}
+	if _, err := k.Verify(token); err != ErrReplay {
+		t.Fatalf("second Verify: got %v, want ErrReplay", err)
+	}
+}
+
+func TestVerifyAllowsReusingAnUnscopedPollToken(t *testing.T) {
+	k := testKeyring()
+
+	token, err := k.Mint("runner-1", "", "", time.Minute)
+	if err != nil {
+		t.Fatalf("Mint: %v", err)
+	}
+
+	if _, err := k.Verify(token); err != nil {
+		t.Fatalf("first Verify: %v", err)
+	}
+	if _, err := k.Verify(token); err != nil {
+		t.Fatalf("second Verify (poll tokens are reusable until they expire): %v", err)
+	}
+}
+
+func TestVerifyRejectsUnknownKeyID(t *testing.T) {
+	minter := testKeyring()
+	verifier := NewKeyring(map[string][]byte{"k2": []byte("other-secret")}, "k2")
+
+	token, err := minter.Mint("runner-1", "", "", time.Minute)
+	if err != nil {
+		t.Fatalf("Mint: %v", err)
+	}
+
+	if _, err := verifier.Verify(token); err == nil {
+		t.Fatal("Verify succeeded against a keyring that doesn't hold the signing key")
+	}
+}
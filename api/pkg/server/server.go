@@ -8,6 +8,8 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/lukemarsden/helix/api/pkg/controller"
+	"github.com/lukemarsden/helix/api/pkg/server/idle"
+	"github.com/lukemarsden/helix/api/pkg/server/runnertoken"
 	"github.com/lukemarsden/helix/api/pkg/store"
 	"github.com/lukemarsden/helix/api/pkg/system"
 )
@@ -27,12 +29,30 @@ type ServerOptions struct {
 	// (this is so helix nodes can see files)
 	// later, we might add a token to the URLs
 	LocalFilestorePath string
+	// how long the server waits, after it has seen zero active (non-hijacked)
+	// connections, before treating itself as idle and completing Shutdown
+	ShutdownIdleTimeout time.Duration
+	// shared secret used to mint new runner tokens, and the key ID new tokens
+	// are stamped with, see pkg/server/runnertoken
+	RunnerTokenSecret string
+	RunnerTokenKeyID  string
+	// secrets for key IDs that are being retired: tokens already minted under
+	// these can still verify until they expire, but new tokens are always
+	// signed with RunnerTokenSecret/RunnerTokenKeyID. Roll a secret by moving
+	// the current RunnerTokenSecret here under its RunnerTokenKeyID, setting
+	// RunnerTokenSecret/RunnerTokenKeyID to the new secret and a new key ID,
+	// deploying, then dropping the entry once tokens minted under it expire
+	RunnerTokenRetiredSecrets map[string]string
+	// optional path to a models.yaml to load on top of the built-in models,
+	// see controller.LoadModelRegistryFile
+	ModelsConfigPath string
 }
 
 type HelixAPIServer struct {
-	Options    ServerOptions
-	Store      store.Store
-	Controller *controller.Controller
+	Options      ServerOptions
+	Store        store.Store
+	Controller   *controller.Controller
+	RunnerTokens *runnertoken.Keyring
 }
 
 func NewServer(
@@ -55,15 +75,36 @@ func NewServer(
 	if options.KeyCloakToken == "" {
 		return nil, fmt.Errorf("keycloak token is required")
 	}
+	if options.ShutdownIdleTimeout == 0 {
+		options.ShutdownIdleTimeout = time.Second * 5
+	}
+	if options.RunnerTokenSecret == "" {
+		return nil, fmt.Errorf("runner token secret is required")
+	}
+	if options.RunnerTokenKeyID == "" {
+		options.RunnerTokenKeyID = "default"
+	}
+
+	keys := map[string][]byte{
+		options.RunnerTokenKeyID: []byte(options.RunnerTokenSecret),
+	}
+	for keyID, secret := range options.RunnerTokenRetiredSecrets {
+		keys[keyID] = []byte(secret)
+	}
 
 	return &HelixAPIServer{
-		Options:    options,
-		Store:      store,
-		Controller: controller,
+		Options:      options,
+		Store:        store,
+		Controller:   controller,
+		RunnerTokens: runnertoken.NewKeyring(keys, options.RunnerTokenKeyID),
 	}, nil
 }
 
 func (apiServer *HelixAPIServer) ListenAndServe(ctx context.Context, cm *system.CleanupManager) error {
+	if err := apiServer.loadModelRegistry(); err != nil {
+		return err
+	}
+
 	router := mux.NewRouter()
 	router.Use(apiServer.corsMiddleware)
 
@@ -82,14 +123,32 @@ func (apiServer *HelixAPIServer) ListenAndServe(ctx context.Context, cm *system.
 		SilenceErrors: true,
 	})).Methods("GET")
 
+	// lets non-Go bindings be generated from the same route table this file
+	// defines, see pkg/server/openapi.go
+	subrouter.HandleFunc("/openapi.json", apiServer.openAPI(router)).Methods("GET")
+
 	authRouter.HandleFunc("/status", Wrapper(apiServer.status)).Methods("GET")
 	authRouter.HandleFunc("/transactions", Wrapper(apiServer.getTransactions)).Methods("GET")
 
+	// lets UIs render dynamic forms from each model's InputSchema/OutputSchema
+	// instead of hard-coding a form per model
+	authRouter.HandleFunc("/models", Wrapper(apiServer.getModels)).Methods("GET")
+
 	authRouter.HandleFunc("/filestore/config", Wrapper(apiServer.filestoreConfig)).Methods("GET")
 	authRouter.HandleFunc("/filestore/list", Wrapper(apiServer.filestoreList)).Methods("GET")
 	authRouter.HandleFunc("/filestore/get", Wrapper(apiServer.filestoreGet)).Methods("GET")
 	authRouter.HandleFunc("/filestore/folder", Wrapper(apiServer.filestoreCreateFolder)).Methods("POST")
-	authRouter.HandleFunc("/filestore/upload", Wrapper(apiServer.filestoreUpload)).Methods("POST")
+	// a plain multipart POST goes straight to filestoreUpload; a tus client
+	// instead sends Upload-Length/Upload-Concat and gets a resumable upload
+	// Location back, see createFilestoreUpload
+	authRouter.HandleFunc("/filestore/upload", apiServer.createFilestoreUpload).Methods("POST")
+
+	// tus (https://tus.io) resumable upload protocol: a client creates an
+	// upload with Upload-Length/Upload-Metadata above, then PATCHes chunks
+	// here identified by the {id} Location returned from the POST
+	authRouter.HandleFunc("/filestore/upload/{id}", apiServer.headTusUpload).Methods("HEAD")
+	authRouter.HandleFunc("/filestore/upload/{id}", apiServer.patchTusUpload).Methods("PATCH")
+
 	authRouter.HandleFunc("/filestore/rename", Wrapper(apiServer.filestoreRename)).Methods("PUT")
 	authRouter.HandleFunc("/filestore/delete", Wrapper(apiServer.filestoreDelete)).Methods("DELETE")
 
@@ -111,20 +170,40 @@ func (apiServer *HelixAPIServer) ListenAndServe(ctx context.Context, cm *system.
 	authRouter.HandleFunc("/sessions/{id}", Wrapper(apiServer.updateSession)).Methods("PUT")
 	authRouter.HandleFunc("/sessions/{id}", Wrapper(apiServer.deleteSession)).Methods("DELETE")
 
-	// TODO: this has no auth right now
-	// we need to add JWTs to the urls we are using to connect models to the workers
-	// the task filters (mode, type and modelName) are all given as query params
-	subrouter.HandleFunc("/runner/{runnerid}/nextsession", WrapperWithConfig(apiServer.getNextRunnerSession, WrapperConfig{
+	// streams newline-delimited JSON progress events (docker build/pull style)
+	// for the lifetime of the session, so clients can render a live progress bar
+	authRouter.HandleFunc("/sessions/{id}/events", apiServer.streamSessionEvents).Methods("GET")
+
+	// runner routes are authorized by short-lived, pre-signed JWTs rather than
+	// the keycloak session cookie - see pkg/server/runnertoken - since the
+	// runner is a headless process with no user attached to it
+
+	// registration has to live outside runnerTokenMiddleware: it's how a
+	// runner gets its very first token, before it has anything to present
+	subrouter.HandleFunc("/runner/{runnerid}/register", Wrapper(apiServer.registerRunner)).Methods("POST")
+
+	runnerRouter := subrouter.PathPrefix("/runner").Subrouter()
+	runnerRouter.Use(apiServer.runnerTokenMiddleware)
+
+	runnerRouter.HandleFunc("/{runnerid}/nextsession", WrapperWithConfig(apiServer.getNextRunnerSession, WrapperConfig{
 		SilenceErrors: true,
 	})).Methods("GET")
 
-	subrouter.HandleFunc("/runner/{runnerid}/response", Wrapper(apiServer.respondRunnerSession)).Methods("POST")
+	runnerRouter.HandleFunc("/{runnerid}/response", Wrapper(apiServer.respondRunnerSession)).Methods("POST")
 
 	// handle downloading a single file from a session to a runner
-	subrouter.HandleFunc("/runner/{runnerid}/session/{sessionid}/download", apiServer.runnerSessionDownloadFile).Methods("GET")
+	runnerRouter.HandleFunc("/{runnerid}/session/{sessionid}/download", apiServer.runnerSessionDownloadFile).Methods("GET")
 
 	// all files uploaded will be put under the "sessions/{sessionid}/results" folder in the filestore
-	subrouter.HandleFunc("/runner/{runnerid}/session/{sessionid}/upload", Wrapper(apiServer.runnerSessionUploadFiles)).Methods("POST")
+	// (or resumed via the tus routes below, for large LoRA checkpoints)
+	runnerRouter.HandleFunc("/{runnerid}/session/{sessionid}/upload", apiServer.createRunnerSessionUpload).Methods("POST")
+	runnerRouter.HandleFunc("/{runnerid}/session/{sessionid}/upload/{uploadid}", apiServer.headTusUpload).Methods("HEAD")
+	runnerRouter.HandleFunc("/{runnerid}/session/{sessionid}/upload/{uploadid}", apiServer.patchTusUpload).Methods("PATCH")
+
+	// mirror of /sessions/{id}/events: the runner posts its own ND-JSON progress
+	// stream here as it runs the job, and we re-publish each event onto the
+	// session's event stream for /sessions/{id}/events subscribers
+	runnerRouter.HandleFunc("/{runnerid}/session/{sessionid}/events", Wrapper(apiServer.runnerSessionEvents)).Methods("POST")
 
 	StartWebSocketServer(
 		ctx,
@@ -134,6 +213,10 @@ func (apiServer *HelixAPIServer) ListenAndServe(ctx context.Context, cm *system.
 		keyCloakMiddleware.userIDFromRequest,
 	)
 
+	apiServer.startUploadSweeper(ctx, cm)
+
+	tracker := idle.NewTracker(apiServer.Options.ShutdownIdleTimeout)
+
 	srv := &http.Server{
 		Addr:              fmt.Sprintf("%s:%d", apiServer.Options.Host, apiServer.Options.Port),
 		WriteTimeout:      time.Minute * 15,
@@ -141,6 +224,57 @@ func (apiServer *HelixAPIServer) ListenAndServe(ctx context.Context, cm *system.
 		ReadHeaderTimeout: time.Minute * 15,
 		IdleTimeout:       time.Minute * 60,
 		Handler:           router,
+		ConnState:         tracker.ConnState,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	shutdown := make(chan struct{})
+	cm.RegisterCallbackWithContext(func(shutdownCtx context.Context) error {
+		// tell connected clients to reconnect elsewhere rather than just
+		// dropping them when we close their underlying connections
+		apiServer.Controller.SessionUpdatesChan <- "server draining"
+
+		// only now start treating "no active connections" as a reason to
+		// exit - before this call tracker.Done() never fires, so a server
+		// that's merely idle right after boot doesn't shut itself down
+		tracker.RequestShutdown()
+		select {
+		case <-tracker.Done():
+		case <-shutdownCtx.Done():
+		}
+
+		err := srv.Shutdown(shutdownCtx)
+		close(shutdown)
+		return err
+	})
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-shutdown:
+		return nil
+	}
+}
+
+// loadModelRegistry populates apiServer.Controller.Models with the built-in
+// models plus, if configured, whatever models.yaml declares on top of them.
+// Without this, GET /models and getNextRunnerSession's runner matching would
+// be working against a nil registry.
+func (apiServer *HelixAPIServer) loadModelRegistry() error {
+	if apiServer.Controller.Models == nil {
+		apiServer.Controller.Models = controller.NewModelRegistry()
+	}
+	if err := controller.RegisterBuiltinModels(apiServer.Controller.Models); err != nil {
+		return fmt.Errorf("registering builtin models: %w", err)
+	}
+	if apiServer.Options.ModelsConfigPath != "" {
+		if err := controller.LoadModelRegistryFile(apiServer.Controller.Models, apiServer.Options.ModelsConfigPath); err != nil {
+			return fmt.Errorf("loading %s: %w", apiServer.Options.ModelsConfigPath, err)
+		}
 	}
-	return srv.ListenAndServe()
+	return nil
 }
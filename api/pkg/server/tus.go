@@ -0,0 +1,327 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lukemarsden/helix/api/pkg/store"
+	"github.com/lukemarsden/helix/api/pkg/system"
+)
+
+// uploadSweepInterval is how often startUploadSweeper calls sweepStaleUploads.
+const uploadSweepInterval = time.Hour
+
+// uploadExpireTTL bounds how long an unfinished upload's record is kept
+// before sweepStaleUploads expires it via Store.ExpireStale. Its staged bytes
+// under stagingDir are not removed by the sweep.
+const uploadExpireTTL = time.Hour * 24
+
+const tusResumableVersion = "1.0.0"
+
+func (apiServer *HelixAPIServer) stagingDir() string {
+	return filepath.Join(apiServer.Options.LocalFilestorePath, ".uploads")
+}
+
+func (apiServer *HelixAPIServer) stagingFilePath(id string) string {
+	return filepath.Join(apiServer.stagingDir(), id)
+}
+
+// parseUploadMetadata decodes the tus Upload-Metadata header, a comma
+// separated list of "key base64(value)" pairs.
+func parseUploadMetadata(header string) map[string]string {
+	metadata := map[string]string{}
+	if header == "" {
+		return metadata
+	}
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.SplitN(strings.TrimSpace(pair), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil {
+			continue
+		}
+		metadata[fields[0]] = string(value)
+	}
+	return metadata
+}
+
+func uploadIDFromRequest(r *http.Request) string {
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; ok {
+		return id
+	}
+	return vars["uploadid"]
+}
+
+// createTusUpload handles the tus POST that creates a new resumable upload.
+// targetPath derives the eventual filestore destination from the decoded
+// Upload-Metadata, e.g. the "path" key for /filestore/upload or
+// "sessions/{sessionid}/results/{filename}" for the runner result upload.
+func (apiServer *HelixAPIServer) createTusUpload(w http.ResponseWriter, r *http.Request, targetPath func(metadata map[string]string) string, locationPrefix string) {
+	ctx := r.Context()
+	metadata := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+
+	length := int64(-1)
+	if raw := r.Header.Get("Upload-Length"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid Upload-Length", http.StatusBadRequest)
+			return
+		}
+		length = parsed
+	}
+
+	var partialIDs []string
+	concatType := ""
+	switch concat := r.Header.Get("Upload-Concat"); {
+	case strings.HasPrefix(concat, "final;"):
+		partialIDs = strings.Fields(strings.TrimPrefix(concat, "final;"))
+		concatType = "final"
+	case concat == "partial":
+		concatType = "partial"
+	case length < 0:
+		http.Error(w, "Upload-Length or Upload-Concat: final is required", http.StatusBadRequest)
+		return
+	}
+
+	id := uuid.NewString()
+	upload := store.Upload{
+		ID:               id,
+		Length:           length,
+		Metadata:         metadata,
+		TargetPath:       targetPath(metadata),
+		PartialUploadIDs: partialIDs,
+		Concat:           concatType,
+	}
+
+	if _, err := apiServer.Store.CreateUpload(ctx, upload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if len(partialIDs) == 0 {
+		if err := os.MkdirAll(apiServer.stagingDir(), 0755); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		f, err := os.Create(apiServer.stagingFilePath(id))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		f.Close()
+	} else if err := apiServer.finalizeUpload(ctx, id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%s", locationPrefix, id))
+	w.Header().Set("Tus-Resumable", tusResumableVersion)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// isTusRequest reports whether a POST to an upload-creation route is a tus
+// resumable upload rather than a plain multipart one.
+func isTusRequest(r *http.Request) bool {
+	return r.Header.Get("Upload-Length") != "" || r.Header.Get("Upload-Concat") != ""
+}
+
+// createFilestoreUpload dispatches POST /filestore/upload to either the tus
+// resumable protocol or the existing plain multipart handler.
+func (apiServer *HelixAPIServer) createFilestoreUpload(w http.ResponseWriter, r *http.Request) {
+	if !isTusRequest(r) {
+		Wrapper(apiServer.filestoreUpload)(w, r)
+		return
+	}
+	apiServer.createTusUpload(w, r, func(metadata map[string]string) string {
+		return metadata["path"]
+	}, r.URL.Path)
+}
+
+// createRunnerSessionUpload dispatches POST .../upload to either the tus
+// resumable protocol or the existing plain multipart handler. Result files
+// always land under "sessions/{sessionid}/results", matching the existing
+// convention used by runnerSessionUploadFiles.
+func (apiServer *HelixAPIServer) createRunnerSessionUpload(w http.ResponseWriter, r *http.Request) {
+	if !isTusRequest(r) {
+		Wrapper(apiServer.runnerSessionUploadFiles)(w, r)
+		return
+	}
+	sessionID := mux.Vars(r)["sessionid"]
+	apiServer.createTusUpload(w, r, func(metadata map[string]string) string {
+		return filepath.Join("sessions", sessionID, "results", metadata["filename"])
+	}, r.URL.Path)
+}
+
+// headTusUpload reports how much of an upload has been durably received so
+// far, per the tus HEAD semantics.
+func (apiServer *HelixAPIServer) headTusUpload(w http.ResponseWriter, r *http.Request) {
+	upload, err := apiServer.Store.GetUpload(r.Context(), uploadIDFromRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	if upload.Length >= 0 {
+		w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	}
+	w.Header().Set("Tus-Resumable", tusResumableVersion)
+	w.WriteHeader(http.StatusOK)
+}
+
+// patchTusUpload appends a chunk of bytes at Upload-Offset, and finalizes
+// the upload into the filestore once the full length has been received -
+// unless it's a partial upload (Upload-Concat: partial), which stays staged
+// until a matching Upload-Concat: final request concatenates it in.
+func (apiServer *HelixAPIServer) patchTusUpload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := uploadIDFromRequest(r)
+
+	upload, err := apiServer.Store.GetUpload(ctx, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+	if offset != upload.Offset {
+		http.Error(w, "Upload-Offset does not match current offset", http.StatusConflict)
+		return
+	}
+
+	f, err := os.OpenFile(apiServer.stagingFilePath(id), os.O_WRONLY, 0644)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	written, err := io.Copy(f, r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	upload, err = apiServer.Store.UpdateOffset(ctx, id, offset+written)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// A partial upload's bytes arriving in full doesn't mean it's done - it
+	// stays staged until the matching Upload-Concat: final request
+	// concatenates it in, so don't move it out of staging here.
+	if upload.Concat != "partial" && upload.Length >= 0 && upload.Offset >= upload.Length {
+		if err := apiServer.finalizeUpload(ctx, id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Tus-Resumable", tusResumableVersion)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// finalizeUpload concatenates any partial uploads (Upload-Concat: final), or
+// just moves the single staged file, atomically into the existing filestore
+// layout used by filestoreUpload.
+func (apiServer *HelixAPIServer) finalizeUpload(ctx context.Context, id string) error {
+	upload, err := apiServer.Store.GetUpload(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	destination := filepath.Join(apiServer.Options.LocalFilestorePath, upload.TargetPath)
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return err
+	}
+
+	if len(upload.PartialUploadIDs) == 0 {
+		if err := os.Rename(apiServer.stagingFilePath(id), destination); err != nil {
+			return err
+		}
+	} else {
+		// assemble the parts in staging first, so the destination only ever
+		// appears complete
+		staged := apiServer.stagingFilePath(id)
+		out, err := os.Create(staged)
+		if err != nil {
+			return err
+		}
+		for _, partID := range upload.PartialUploadIDs {
+			if err := appendStagedFile(out, apiServer.stagingFilePath(partID)); err != nil {
+				out.Close()
+				return err
+			}
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		if err := os.Rename(staged, destination); err != nil {
+			return err
+		}
+	}
+
+	return apiServer.Store.FinalizeUpload(ctx, id)
+}
+
+func appendStagedFile(out io.Writer, path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// sweepStaleUploads GCs unfinished uploads older than uploadExpireTTL.
+func (apiServer *HelixAPIServer) sweepStaleUploads(ctx context.Context) error {
+	_, err := apiServer.Store.ExpireStale(ctx, time.Now().Add(-uploadExpireTTL))
+	return err
+}
+
+// startUploadSweeper registers a background goroutine with cm that
+// periodically calls sweepStaleUploads until cm runs its cleanup callbacks.
+func (apiServer *HelixAPIServer) startUploadSweeper(ctx context.Context, cm *system.CleanupManager) {
+	stop := make(chan struct{})
+	cm.RegisterCallbackWithContext(func(context.Context) error {
+		close(stop)
+		return nil
+	})
+
+	go func() {
+		ticker := time.NewTicker(uploadSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = apiServer.sweepStaleUploads(ctx)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseUploadMetadata(t *testing.T) {
+	// "path" -> base64("sessions/s1/input"), "filename" -> base64("input.bin")
+	header := "path c2Vzc2lvbnMvczEvaW5wdXQ=,filename aW5wdXQuYmlu"
+
+	metadata := parseUploadMetadata(header)
+
+	if metadata["path"] != "sessions/s1/input" {
+		t.Fatalf("path = %q", metadata["path"])
+	}
+	if metadata["filename"] != "input.bin" {
+		t.Fatalf("filename = %q", metadata["filename"])
+	}
+}
+
+func TestParseUploadMetadataEmptyHeader(t *testing.T) {
+	if metadata := parseUploadMetadata(""); len(metadata) != 0 {
+		t.Fatalf("expected empty metadata, got %v", metadata)
+	}
+}
+
+func TestAppendStagedFileConcatenatesInOrder(t *testing.T) {
+	dir := t.TempDir()
+
+	part1 := filepath.Join(dir, "part1")
+	part2 := filepath.Join(dir, "part2")
+	if err := os.WriteFile(part1, []byte("hello "), 0644); err != nil {
+		t.Fatalf("write part1: %v", err)
+	}
+	if err := os.WriteFile(part2, []byte("world"), 0644); err != nil {
+		t.Fatalf("write part2: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := appendStagedFile(&out, part1); err != nil {
+		t.Fatalf("appendStagedFile part1: %v", err)
+	}
+	if err := appendStagedFile(&out, part2); err != nil {
+		t.Fatalf("appendStagedFile part2: %v", err)
+	}
+
+	if out.String() != "hello world" {
+		t.Fatalf("got %q, want %q", out.String(), "hello world")
+	}
+}
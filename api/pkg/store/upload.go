@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Upload tracks the state of a single in-progress tus (https://tus.io)
+// resumable upload, so a dropped connection during filestoreUpload or
+// runnerSessionUploadFiles can resume instead of restarting the whole
+// transfer. Store.Store embeds UploadStore so callers just use the existing
+// apiServer.Store.
+type Upload struct {
+	ID string `json:"id"`
+	// Offset is how many bytes have been durably appended so far.
+	Offset int64 `json:"offset"`
+	// Length is the total expected size, or -1 when the creating request sent
+	// no Upload-Length (an Upload-Concat: final upload, or a partial one).
+	Length int64 `json:"length"`
+	// Metadata holds the decoded Upload-Metadata key/value pairs, e.g.
+	// filename, session id and destination path within the filestore.
+	Metadata map[string]string `json:"metadata"`
+	// TargetPath is where FinalizeUpload moves the assembled file to within
+	// the existing filestore layout.
+	TargetPath string `json:"target_path"`
+	// PartialUploadIDs is set for an Upload-Concat: final upload, naming the
+	// partial uploads to concatenate, in order.
+	PartialUploadIDs []string `json:"partial_upload_ids,omitempty"`
+	// Concat is "partial" for an Upload-Concat: partial upload, "final" for
+	// an Upload-Concat: final upload, or empty for an ordinary upload. A
+	// partial upload's own bytes fully arriving does not mean it is done -
+	// it is only complete once the matching final upload has concatenated
+	// it in, so patchTusUpload must not finalize it into the filestore on
+	// its own.
+	Concat    string    `json:"concat,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UploadStore persists tus upload state. A background sweeper registered
+// with system.CleanupManager calls ExpireStale to GC unfinished uploads that
+// have outlived their TTL.
+type UploadStore interface {
+	CreateUpload(ctx context.Context, upload Upload) (Upload, error)
+	GetUpload(ctx context.Context, id string) (Upload, error)
+	UpdateOffset(ctx context.Context, id string, offset int64) (Upload, error)
+	FinalizeUpload(ctx context.Context, id string) error
+	ExpireStale(ctx context.Context, olderThan time.Time) (int, error)
+}